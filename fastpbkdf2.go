@@ -10,8 +10,10 @@ A key derivation function is useful when encrypting data based on a password
 or any other not-fully-random data. It uses a pseudorandom function to derive
 a secure encryption key based on the password.
 
-PBKDF2-HMAC-SHA1 is the only supported hash function, for now.
-PBKDF2-HMAC-SHA2 will be supported in the future.
+PBKDF2-HMAC-SHA1 and PBKDF2-HMAC-SHA2 (SHA-224/256/384/512) are supported
+directly as SHA1, SHA224, SHA256, SHA384 and SHA512. Key provides a
+golang.org/x/crypto/pbkdf2-compatible entry point for callers that select
+the hash function at runtime.
 */
 package fastpbkdf2
 
@@ -180,3 +182,39 @@ func putUint32(x []byte, s uint32) {
 	x[2] = byte(s >> 8)
 	x[3] = byte(s)
 }
+
+func readUint64(x []byte) uint64 {
+	_ = x[7]
+	return uint64(x[0])<<56 | uint64(x[1])<<48 | uint64(x[2])<<40 | uint64(x[3])<<32 |
+		uint64(x[4])<<24 | uint64(x[5])<<16 | uint64(x[6])<<8 | uint64(x[7])
+}
+
+func putUint64(x []byte, s uint64) {
+	_ = x[7]
+	x[0] = byte(s >> 56)
+	x[1] = byte(s >> 48)
+	x[2] = byte(s >> 40)
+	x[3] = byte(s >> 32)
+	x[4] = byte(s >> 24)
+	x[5] = byte(s >> 16)
+	x[6] = byte(s >> 8)
+	x[7] = byte(s)
+}
+
+// sha2_pad writes SHA-2 padding into b in place for a message of len bytes
+// in total, given that b is exactly blockSize long and its first
+// len%blockSize bytes already hold the message's final partial block.
+// It's shared by the SHA-256 and SHA-512 families: both terminate the
+// message with a 0x80 byte, zero padding, and a big-endian bit length in
+// the last 8 bytes of the block. SHA-512's 128-bit length field is handled
+// by the same code because the extra high-order 8 bytes fall within the
+// zero-padded region and messages here never approach 2^64 bits.
+func sha2_pad(b []byte, len uint, blockSize int) {
+	nx := int(len) % blockSize
+	bits := uint64(len) * 8
+	b[nx] = 0x80
+	for i := nx + 1; i < blockSize-8; i++ {
+		b[i] = 0
+	}
+	putUint64(b[blockSize-8:], bits)
+}
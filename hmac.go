@@ -0,0 +1,239 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastpbkdf2
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+)
+
+// HMAC returns a streaming hash.Hash implementing HMAC with the hash
+// identified by hashID, which must be one of crypto.SHA1, crypto.SHA224,
+// crypto.SHA256, crypto.SHA384 or crypto.SHA512. Unlike crypto/hmac.New, it
+// reuses this package's precomputed inner/outer compression states (the
+// same ones hmac_init builds for the PBKDF2 fast paths), so repeated
+// keying, as happens when verifying many stored hashes with the same
+// password-derived key, avoids crypto/hmac's per-call re-keying cost.
+//
+// HMAC panics if hashID is not one of the hashes listed above.
+func HMAC(hashID crypto.Hash, key []byte) hash.Hash {
+	switch hashID {
+	case crypto.SHA1:
+		var inner, outer block
+		hmac_init(&inner, &outer, key)
+		return &fastHMAC32{
+			h: inner, initState: inner, outer: outer,
+			inputFn: sha1_input, outputFn: sha1_output, blockFn: sha1_block,
+			blockSize: chunk, size: sha1.Size,
+		}
+	case crypto.SHA224:
+		return newFastHMAC256family(key, sha256.New224, sha224_init, sha256.Size224)
+	case crypto.SHA256:
+		return newFastHMAC256family(key, sha256.New, sha256_init, sha256.Size)
+	case crypto.SHA384:
+		return newFastHMAC512family(key, sha512.New384, sha384_init, sha512.Size384)
+	case crypto.SHA512:
+		return newFastHMAC512family(key, sha512.New, sha512_init, sha512.Size)
+	default:
+		panic("fastpbkdf2: HMAC: unsupported hash " + hashID.String())
+	}
+}
+
+func newFastHMAC256family(key []byte, newHash func() hash.Hash, initFn func(*block), digestSize int) hash.Hash {
+	var inner, outer block
+	hmac_init256(&inner, &outer, key, newHash, initFn)
+	return &fastHMAC32{
+		h: inner, initState: inner, outer: outer,
+		inputFn: sha256_input, outputFn: sha256_output, blockFn: sha256_block,
+		blockSize: sha256.BlockSize, size: digestSize,
+	}
+}
+
+func newFastHMAC512family(key []byte, newHash func() hash.Hash, initFn func(*block64), digestSize int) hash.Hash {
+	var inner, outer block64
+	hmac_init512(&inner, &outer, key, newHash, initFn)
+	return &fastHMAC64{
+		h: inner, initState: inner, outer: outer,
+		inputFn: sha512_input, outputFn: sha512_output, blockFn: sha512_block,
+		blockSize: sha512.BlockSize, size: digestSize,
+	}
+}
+
+// fastHMAC32 is a streaming HMAC built on the 32-bit-word (SHA-1/SHA-2-256)
+// block family. h is the running hash state, seeded from initState (the
+// precomputed H(ipad) state), over whatever message bytes have been
+// written so far.
+type fastHMAC32 struct {
+	h, initState, outer block
+	x                   [64]byte
+	nx                  int
+	len                 uint64
+	inputFn             func(*block, []byte)
+	outputFn            func([]byte, *block)
+	blockFn             func(dst, init, src *block)
+	blockSize, size     int
+}
+
+func (d *fastHMAC32) Size() int      { return d.size }
+func (d *fastHMAC32) BlockSize() int { return d.blockSize }
+
+func (d *fastHMAC32) Reset() {
+	d.h = d.initState
+	d.nx = 0
+	d.len = 0
+}
+
+func (d *fastHMAC32) Write(p []byte) (n int, err error) {
+	n = len(p)
+	d.len += uint64(n)
+	if d.nx > 0 {
+		c := copy(d.x[d.nx:], p)
+		d.nx += c
+		if d.nx == d.blockSize {
+			var in block
+			d.inputFn(&in, d.x[:])
+			d.blockFn(&d.h, &d.h, &in)
+			d.nx = 0
+		}
+		p = p[c:]
+	}
+	for len(p) >= d.blockSize {
+		var in block
+		d.inputFn(&in, p[:d.blockSize])
+		d.blockFn(&d.h, &d.h, &in)
+		p = p[d.blockSize:]
+	}
+	if len(p) > 0 {
+		d.nx = copy(d.x[:], p)
+	}
+	return n, nil
+}
+
+func (d *fastHMAC32) Sum(in []byte) []byte {
+	// Work on a copy so callers can keep writing after Sum, per hash.Hash.
+	dCopy := *d
+	dCopy.pad()
+
+	var innerOut [32]byte
+	d.outputFn(innerOut[:d.size], &dCopy.h)
+
+	outer := d.outer
+	var pad [64]byte
+	copy(pad[:], innerOut[:d.size])
+	sha2_pad(pad[:d.blockSize], uint(d.blockSize+d.size), d.blockSize)
+	var inBlock block
+	d.inputFn(&inBlock, pad[:d.blockSize])
+	d.blockFn(&outer, &outer, &inBlock)
+
+	var out [32]byte
+	d.outputFn(out[:d.size], &outer)
+	return append(in, out[:d.size]...)
+}
+
+// pad feeds the SHA-2-style 0x80/zero/length padding for the message
+// written so far (plus the precomputed ipad block) through Write, leaving
+// d.h holding the finished H(ipad||message) state.
+func (d *fastHMAC32) pad() {
+	totalLen := uint64(d.blockSize) + d.len
+	var tmp [128]byte
+	tmp[0] = 0x80
+	t := int(d.len % uint64(d.blockSize))
+	if t < d.blockSize-8 {
+		d.Write(tmp[0 : d.blockSize-8-t])
+	} else {
+		d.Write(tmp[0 : d.blockSize+d.blockSize-8-t])
+	}
+	var lenBuf [8]byte
+	putUint64(lenBuf[:], totalLen*8)
+	d.Write(lenBuf[:])
+}
+
+// fastHMAC64 is a streaming HMAC built on the 64-bit-word (SHA-2-512)
+// block family; see fastHMAC32 for the general approach.
+type fastHMAC64 struct {
+	h, initState, outer block64
+	x                   [128]byte
+	nx                  int
+	len                 uint64
+	inputFn             func(*block64, []byte)
+	outputFn            func([]byte, *block64)
+	blockFn             func(dst, init, src *block64)
+	blockSize, size     int
+}
+
+func (d *fastHMAC64) Size() int      { return d.size }
+func (d *fastHMAC64) BlockSize() int { return d.blockSize }
+
+func (d *fastHMAC64) Reset() {
+	d.h = d.initState
+	d.nx = 0
+	d.len = 0
+}
+
+func (d *fastHMAC64) Write(p []byte) (n int, err error) {
+	n = len(p)
+	d.len += uint64(n)
+	if d.nx > 0 {
+		c := copy(d.x[d.nx:], p)
+		d.nx += c
+		if d.nx == d.blockSize {
+			var in block64
+			d.inputFn(&in, d.x[:])
+			d.blockFn(&d.h, &d.h, &in)
+			d.nx = 0
+		}
+		p = p[c:]
+	}
+	for len(p) >= d.blockSize {
+		var in block64
+		d.inputFn(&in, p[:d.blockSize])
+		d.blockFn(&d.h, &d.h, &in)
+		p = p[d.blockSize:]
+	}
+	if len(p) > 0 {
+		d.nx = copy(d.x[:], p)
+	}
+	return n, nil
+}
+
+func (d *fastHMAC64) Sum(in []byte) []byte {
+	dCopy := *d
+	dCopy.pad()
+
+	var innerOut [64]byte
+	d.outputFn(innerOut[:d.size], &dCopy.h)
+
+	outer := d.outer
+	var pad [128]byte
+	copy(pad[:], innerOut[:d.size])
+	sha2_pad(pad[:d.blockSize], uint(d.blockSize+d.size), d.blockSize)
+	var inBlock block64
+	d.inputFn(&inBlock, pad[:d.blockSize])
+	d.blockFn(&outer, &outer, &inBlock)
+
+	var out [64]byte
+	d.outputFn(out[:d.size], &outer)
+	return append(in, out[:d.size]...)
+}
+
+func (d *fastHMAC64) pad() {
+	totalLen := uint64(d.blockSize) + d.len
+	var tmp [256]byte
+	tmp[0] = 0x80
+	t := int(d.len % uint64(d.blockSize))
+	// SHA-512/384 use a 128-bit length field; leave 16 bytes free (8 zero
+	// bytes for the unused high word plus the 8-byte lenBuf below), not 8.
+	if t < d.blockSize-16 {
+		d.Write(tmp[0 : d.blockSize-8-t])
+	} else {
+		d.Write(tmp[0 : d.blockSize+d.blockSize-8-t])
+	}
+	var lenBuf [8]byte
+	putUint64(lenBuf[:], totalLen*8)
+	d.Write(lenBuf[:])
+}
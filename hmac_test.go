@@ -0,0 +1,92 @@
+package fastpbkdf2
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"testing"
+)
+
+func TestHMAC_matchesStdlib(t *testing.T) {
+	key := []byte("a reasonably long hmac key, long enough to exceed one block maybe not quite")
+	msg := []byte("the quick brown fox jumps over the lazy dog, repeated a few times to span blocks, the quick brown fox jumps over the lazy dog")
+
+	check := func(id crypto.Hash, std func() []byte) {
+		h := HMAC(id, key)
+		// Write in a few odd-sized chunks to exercise the block buffering.
+		for _, c := range [][]byte{msg[:7], msg[7:7], msg[7:70], msg[70:]} {
+			h.Write(c)
+		}
+		if got, want := h.Sum(nil), std(); !bytes.Equal(got, want) {
+			t.Errorf("%v: got %x, want %x", id, got, want)
+		}
+		h.Reset()
+		h.Write(msg)
+		if got, want := h.Sum(nil), std(); !bytes.Equal(got, want) {
+			t.Errorf("%v after Reset: got %x, want %x", id, got, want)
+		}
+	}
+
+	check(crypto.SHA1, func() []byte {
+		m := hmac.New(sha1.New, key)
+		m.Write(msg)
+		return m.Sum(nil)
+	})
+	check(crypto.SHA224, func() []byte {
+		m := hmac.New(sha256.New224, key)
+		m.Write(msg)
+		return m.Sum(nil)
+	})
+	check(crypto.SHA256, func() []byte {
+		m := hmac.New(sha256.New, key)
+		m.Write(msg)
+		return m.Sum(nil)
+	})
+	check(crypto.SHA384, func() []byte {
+		m := hmac.New(sha512.New384, key)
+		m.Write(msg)
+		return m.Sum(nil)
+	})
+	check(crypto.SHA512, func() []byte {
+		m := hmac.New(sha512.New, key)
+		m.Write(msg)
+		return m.Sum(nil)
+	})
+}
+
+// TestHMAC_boundaryLengths exercises message lengths around the SHA-512
+// 128-bit length field, where fastHMAC64.pad must reserve 16 bytes (not 8)
+// at the end of the block for the length; the 64-byte-block HMACs only need
+// an 8-byte length field and have no equivalent boundary.
+func TestHMAC_boundaryLengths(t *testing.T) {
+	key := []byte("boundary length test key")
+
+	for _, id := range []crypto.Hash{crypto.SHA384, crypto.SHA512} {
+		var newHash func() hash.Hash
+		if id == crypto.SHA384 {
+			newHash = sha512.New384
+		} else {
+			newHash = sha512.New
+		}
+		blockSize := sha512.BlockSize
+		for mlen := blockSize - 16; mlen < blockSize; mlen++ {
+			msg := bytes.Repeat([]byte{'x'}, mlen)
+
+			h := HMAC(id, key)
+			h.Write(msg)
+			got := h.Sum(nil)
+
+			std := hmac.New(newHash, key)
+			std.Write(msg)
+			want := std.Sum(nil)
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("%v mlen=%d: got %x, want %x", id, mlen, got, want)
+			}
+		}
+	}
+}
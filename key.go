@@ -0,0 +1,77 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastpbkdf2
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"reflect"
+)
+
+// Key derives a key from the password, salt and iteration count, returning a
+// []byte of length keylen that can be used as cryptographic key, using the
+// HMAC variant of the given hash function h as the PRF.
+//
+// Key is a drop-in replacement for golang.org/x/crypto/pbkdf2.Key: when h is
+// one of sha1.New, sha256.New, sha256.New224, sha512.New or sha512.New384 it
+// dispatches to this package's fast path for that hash; for any other hash
+// function it falls back to a generic HMAC-based PBKDF2 implementation.
+func Key(password, salt []byte, iter, keyLen int, h func() hash.Hash) []byte {
+	switch {
+	case sameHash(h, sha1.New):
+		return SHA1(password, salt, iter, keyLen)
+	case sameHash(h, sha256.New):
+		return SHA256(password, salt, iter, keyLen)
+	case sameHash(h, sha256.New224):
+		return SHA224(password, salt, iter, keyLen)
+	case sameHash(h, sha512.New):
+		return SHA512(password, salt, iter, keyLen)
+	case sameHash(h, sha512.New384):
+		return SHA384(password, salt, iter, keyLen)
+	default:
+		return keyGeneric(password, salt, iter, keyLen, h)
+	}
+}
+
+// sameHash reports whether a and b are the same function, such as
+// sha256.New, identified by comparing their code pointers. Go func values
+// aren't otherwise comparable.
+func sameHash(a, b func() hash.Hash) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// keyGeneric is the unoptimized PBKDF2-HMAC implementation used for hash
+// functions this package doesn't special-case: it re-keys the HMAC for
+// every compression instead of reusing a precomputed inner/outer state.
+func keyGeneric(password, salt []byte, iter, keyLen int, h func() hash.Hash) []byte {
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var buf [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	U := make([]byte, hashLen)
+	for block := 1; block <= numBlocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		putUint32(buf[:], uint32(block))
+		prf.Write(buf[:4])
+		dk = prf.Sum(dk)
+		T := dk[len(dk)-hashLen:]
+		copy(U, T)
+		for n := 2; n <= iter; n++ {
+			prf.Reset()
+			prf.Write(U)
+			U = prf.Sum(U[:0])
+			for x := range U {
+				T[x] ^= U[x]
+			}
+		}
+	}
+	return dk[:keyLen]
+}
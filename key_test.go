@@ -0,0 +1,75 @@
+package fastpbkdf2
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func Test_Key_dispatch(t *testing.T) {
+	password, salt := []byte("password"), []byte("salt")
+
+	if got, want := Key(password, salt, 4, 20, sha1.New), SHA1(password, salt, 4, 20); !bytes.Equal(got, want) {
+		t.Errorf("sha1: got %x, want %x", got, want)
+	}
+	if got, want := Key(password, salt, 4, 32, sha256.New), SHA256(password, salt, 4, 32); !bytes.Equal(got, want) {
+		t.Errorf("sha256: got %x, want %x", got, want)
+	}
+	if got, want := Key(password, salt, 4, 28, sha256.New224), SHA224(password, salt, 4, 28); !bytes.Equal(got, want) {
+		t.Errorf("sha224: got %x, want %x", got, want)
+	}
+	if got, want := Key(password, salt, 4, 64, sha512.New), SHA512(password, salt, 4, 64); !bytes.Equal(got, want) {
+		t.Errorf("sha512: got %x, want %x", got, want)
+	}
+	if got, want := Key(password, salt, 4, 48, sha512.New384), SHA384(password, salt, 4, 48); !bytes.Equal(got, want) {
+		t.Errorf("sha384: got %x, want %x", got, want)
+	}
+}
+
+// Test_Key_matchesXCrypto cross-checks each of Key's fast-path dispatches
+// against golang.org/x/crypto/pbkdf2 directly, with iter > 1 and a keyLen
+// spanning more than one PRF block. Test_Key_dispatch above only compares
+// Key against this package's own SHA1/SHA224/.../SHA512 functions, which
+// share the same implementation and so can't catch a bug common to both.
+func Test_Key_matchesXCrypto(t *testing.T) {
+	password, salt := []byte("passwordPASSWORDpassword"), []byte("saltSALTsaltSALTsaltSALTsaltSALTsalt")
+	const iter = 4096
+
+	tests := []struct {
+		name string
+		h    func() hash.Hash
+		size int
+	}{
+		{"sha1", sha1.New, sha1.Size},
+		{"sha224", sha256.New224, sha256.Size224},
+		{"sha256", sha256.New, sha256.Size},
+		{"sha384", sha512.New384, sha512.Size384},
+		{"sha512", sha512.New, sha512.Size},
+	}
+	for _, tt := range tests {
+		keyLen := 2*tt.size + 7 // spans 3 PRF blocks
+		got := Key(password, salt, iter, keyLen, tt.h)
+		want := pbkdf2.Key(password, salt, iter, keyLen, tt.h)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %x, want %x", tt.name, got, want)
+		}
+	}
+}
+
+func Test_Key_genericFallback(t *testing.T) {
+	password, salt := []byte("password"), []byte("salt")
+	got := Key(password, salt, 4, 16, md5.New)
+	if len(got) != 16 {
+		t.Fatalf("got key of length %d, want 16", len(got))
+	}
+	again := Key(password, salt, 4, 16, md5.New)
+	if !bytes.Equal(got, again) {
+		t.Errorf("Key is not deterministic: %x != %x", got, again)
+	}
+}
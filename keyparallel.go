@@ -0,0 +1,238 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastpbkdf2
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"sync"
+)
+
+// KeyParallel is Key, fanned out across workers goroutines. PBKDF2's output
+// blocks T_i are independent of one another, so for large keyLen (deriving
+// many subkeys, or scrypt's p*128*r-byte expansion) they can be computed
+// concurrently: each worker gets its own PRF and U/tmp scratch state but
+// shares the single precomputed inner/outer HMAC state, which hmac_init
+// never mutates after building it, so no locking is needed on the hot path.
+//
+// workers values less than 1 are treated as 1; KeyParallel never starts
+// more workers than there are output blocks to compute.
+func KeyParallel(password, salt []byte, iter, keyLen, workers int, h func() hash.Hash) []byte {
+	switch {
+	case sameHash(h, sha1.New):
+		return sha1KeyParallel(password, salt, iter, keyLen, workers)
+	case sameHash(h, sha256.New):
+		return sha2_256familyParallel(password, salt, iter, keyLen, workers, sha256.New, sha256_init, sha256.Size, false)
+	case sameHash(h, sha256.New224):
+		return sha2_256familyParallel(password, salt, iter, keyLen, workers, sha256.New224, sha224_init, sha256.Size224, true)
+	case sameHash(h, sha512.New):
+		return sha2_512familyParallel(password, salt, iter, keyLen, workers, sha512.New, sha512_init, sha512.Size, false)
+	case sameHash(h, sha512.New384):
+		return sha2_512familyParallel(password, salt, iter, keyLen, workers, sha512.New384, sha384_init, sha512.Size384, true)
+	default:
+		return keyGenericParallel(password, salt, iter, keyLen, workers, h)
+	}
+}
+
+// runParallel calls workerFn(workerID, workers) once per worker, where
+// workers has been clamped to [1, numBlocks]. Each worker is expected to
+// process block indices workerID+1, workerID+1+workers, ... round-robin.
+func runParallel(numBlocks, workers int, workerFn func(workerID, workers int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > numBlocks {
+		workers = numBlocks
+	}
+	if workers == 1 {
+		workerFn(0, 1)
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			workerFn(w, workers)
+		}(w)
+	}
+	wg.Wait()
+}
+
+func sha1KeyParallel(password, salt []byte, iter, keyLen, workers int) []byte {
+	numBlocks := (keyLen + sha1.Size - 1) / sha1.Size
+
+	var inner, outer block
+	hmac_init(&inner, &outer, password)
+
+	full := make([]byte, numBlocks*sha1.Size)
+	runParallel(numBlocks, workers, func(workerID, workers int) {
+		prf := hmac.New(sha1.New, password)
+		var buf [4]byte
+		var tmp, U block
+		tpad := make([]byte, sha1.BlockSize)
+		for blk := workerID + 1; blk <= numBlocks; blk += workers {
+			prf.Reset()
+			prf.Write(salt)
+			putUint32(buf[:], uint32(blk))
+			prf.Write(buf[:4])
+			T := prf.Sum(nil)
+
+			for i := range tmp.h[:5] {
+				tmp.h[i] = readUint32(T[i*4:])
+			}
+			copy(tpad, T)
+			sha1_pad(tpad, sha1.BlockSize+sha1.Size)
+			sha1_input(&U, tpad)
+			for n := 2; n <= iter; n++ {
+				sha1_block(&U, &inner, &U)
+				sha1_block(&U, &outer, &U)
+				tmp.h[0] ^= U.h[0]
+				tmp.h[1] ^= U.h[1]
+				tmp.h[2] ^= U.h[2]
+				tmp.h[3] ^= U.h[3]
+				tmp.h[4] ^= U.h[4]
+			}
+			sha1_output(full[(blk-1)*sha1.Size:blk*sha1.Size], &tmp)
+		}
+	})
+	return full[:keyLen]
+}
+
+func sha2_256familyParallel(password, salt []byte, iter, keyLen, workers int, newHash func() hash.Hash, initFn func(*block), digestSize int, truncated bool) []byte {
+	numBlocks := (keyLen + digestSize - 1) / digestSize
+
+	var inner, outer block
+	hmac_init256(&inner, &outer, password, newHash, initFn)
+
+	full := make([]byte, numBlocks*digestSize)
+	runParallel(numBlocks, workers, func(workerID, workers int) {
+		prf := hmac.New(newHash, password)
+		var buf [4]byte
+		var tmp, U block
+		tpad := make([]byte, sha256.BlockSize)
+		for blk := workerID + 1; blk <= numBlocks; blk += workers {
+			prf.Reset()
+			prf.Write(salt)
+			putUint32(buf[:], uint32(blk))
+			prf.Write(buf[:4])
+			T := prf.Sum(nil)
+
+			for i := range tmp.h[:digestSize/4] {
+				tmp.h[i] = readUint32(T[i*4:])
+			}
+			copy(tpad, T)
+			sha2_pad(tpad, uint(sha256.BlockSize+digestSize), sha256.BlockSize)
+			sha256_input(&U, tpad)
+			if truncated {
+				for n := 2; n <= iter; n++ {
+					sha256_block(&U, &inner, &U)
+					sha256_retrunc(&U, tpad, digestSize)
+					sha256_block(&U, &outer, &U)
+					sha256_retrunc(&U, tpad, digestSize)
+					for i := 0; i*4 < digestSize; i++ {
+						tmp.h[i] ^= U.h[i]
+					}
+				}
+			} else {
+				for n := 2; n <= iter; n++ {
+					sha256_block(&U, &inner, &U)
+					sha256_block(&U, &outer, &U)
+					for i := 0; i*4 < digestSize; i++ {
+						tmp.h[i] ^= U.h[i]
+					}
+				}
+			}
+			sha256_output(full[(blk-1)*digestSize:blk*digestSize], &tmp)
+		}
+	})
+	return full[:keyLen]
+}
+
+func sha2_512familyParallel(password, salt []byte, iter, keyLen, workers int, newHash func() hash.Hash, initFn func(*block64), digestSize int, truncated bool) []byte {
+	numBlocks := (keyLen + digestSize - 1) / digestSize
+
+	var inner, outer block64
+	hmac_init512(&inner, &outer, password, newHash, initFn)
+
+	full := make([]byte, numBlocks*digestSize)
+	runParallel(numBlocks, workers, func(workerID, workers int) {
+		prf := hmac.New(newHash, password)
+		var buf [4]byte
+		var tmp, U block64
+		tpad := make([]byte, sha512.BlockSize)
+		for blk := workerID + 1; blk <= numBlocks; blk += workers {
+			prf.Reset()
+			prf.Write(salt)
+			putUint32(buf[:], uint32(blk))
+			prf.Write(buf[:4])
+			T := prf.Sum(nil)
+
+			for i := range tmp.h[:digestSize/8] {
+				tmp.h[i] = readUint64(T[i*8:])
+			}
+			copy(tpad, T)
+			sha2_pad(tpad, uint(sha512.BlockSize+digestSize), sha512.BlockSize)
+			sha512_input(&U, tpad)
+			if truncated {
+				for n := 2; n <= iter; n++ {
+					sha512_block(&U, &inner, &U)
+					sha512_retrunc(&U, tpad, digestSize)
+					sha512_block(&U, &outer, &U)
+					sha512_retrunc(&U, tpad, digestSize)
+					for i := 0; i*8 < digestSize; i++ {
+						tmp.h[i] ^= U.h[i]
+					}
+				}
+			} else {
+				for n := 2; n <= iter; n++ {
+					sha512_block(&U, &inner, &U)
+					sha512_block(&U, &outer, &U)
+					for i := 0; i*8 < digestSize; i++ {
+						tmp.h[i] ^= U.h[i]
+					}
+				}
+			}
+			sha512_output(full[(blk-1)*digestSize:blk*digestSize], &tmp)
+		}
+	})
+	return full[:keyLen]
+}
+
+// keyGenericParallel is keyGeneric, fanned out across workers goroutines.
+func keyGenericParallel(password, salt []byte, iter, keyLen, workers int, h func() hash.Hash) []byte {
+	hashLen := hmac.New(h, password).Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	full := make([]byte, numBlocks*hashLen)
+	runParallel(numBlocks, workers, func(workerID, workers int) {
+		prf := hmac.New(h, password)
+		var buf [4]byte
+		U := make([]byte, hashLen)
+		for blk := workerID + 1; blk <= numBlocks; blk += workers {
+			prf.Reset()
+			prf.Write(salt)
+			putUint32(buf[:], uint32(blk))
+			prf.Write(buf[:4])
+			T := prf.Sum(nil)
+
+			copy(U, T)
+			for n := 2; n <= iter; n++ {
+				prf.Reset()
+				prf.Write(U)
+				U = prf.Sum(U[:0])
+				for x := range U {
+					T[x] ^= U[x]
+				}
+			}
+			copy(full[(blk-1)*hashLen:blk*hashLen], T)
+		}
+	})
+	return full[:keyLen]
+}
@@ -0,0 +1,44 @@
+package fastpbkdf2
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+)
+
+func Test_KeyParallel_matchesKey(t *testing.T) {
+	password, salt := []byte("password"), []byte("salt")
+
+	for _, workers := range []int{1, 2, 3, 8} {
+		if got, want := KeyParallel(password, salt, 4, 20*5, workers, sha1.New), Key(password, salt, 4, 20*5, sha1.New); !bytes.Equal(got, want) {
+			t.Errorf("sha1 workers=%d: got %x, want %x", workers, got, want)
+		}
+		if got, want := KeyParallel(password, salt, 4, 32*5, workers, sha256.New), Key(password, salt, 4, 32*5, sha256.New); !bytes.Equal(got, want) {
+			t.Errorf("sha256 workers=%d: got %x, want %x", workers, got, want)
+		}
+		if got, want := KeyParallel(password, salt, 4, 28*5, workers, sha256.New224), Key(password, salt, 4, 28*5, sha256.New224); !bytes.Equal(got, want) {
+			t.Errorf("sha224 workers=%d: got %x, want %x", workers, got, want)
+		}
+		if got, want := KeyParallel(password, salt, 4, 64*5, workers, sha512.New), Key(password, salt, 4, 64*5, sha512.New); !bytes.Equal(got, want) {
+			t.Errorf("sha512 workers=%d: got %x, want %x", workers, got, want)
+		}
+		if got, want := KeyParallel(password, salt, 4, 48*5, workers, sha512.New384), Key(password, salt, 4, 48*5, sha512.New384); !bytes.Equal(got, want) {
+			t.Errorf("sha384 workers=%d: got %x, want %x", workers, got, want)
+		}
+		if got, want := KeyParallel(password, salt, 4, 16*5, workers, md5.New), Key(password, salt, 4, 16*5, md5.New); !bytes.Equal(got, want) {
+			t.Errorf("md5 fallback workers=%d: got %x, want %x", workers, got, want)
+		}
+	}
+}
+
+func Test_KeyParallel_moreWorkersThanBlocks(t *testing.T) {
+	password, salt := []byte("password"), []byte("salt")
+	got := KeyParallel(password, salt, 4, 20, 64, sha1.New)
+	want := Key(password, salt, 4, 20, sha1.New)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package scrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Test vectors from RFC 7914, section 12.
+func TestKeyRFC7914(t *testing.T) {
+	tests := []struct {
+		password, salt  string
+		N, r, p, keyLen int
+		want            string
+	}{
+		{
+			"", "", 16, 1, 1, 64,
+			"77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906",
+		},
+		{
+			"password", "NaCl", 1024, 8, 16, 64,
+			"fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640",
+		},
+	}
+	for i, tt := range tests {
+		got, err := Key([]byte(tt.password), []byte(tt.salt), tt.N, tt.r, tt.p, tt.keyLen)
+		if err != nil {
+			t.Fatalf("%d: Key returned error: %v", i, err)
+		}
+		if want := fromHex(tt.want); !bytes.Equal(got, want) {
+			t.Errorf("%d: got %x, want %x", i, got, want)
+		}
+	}
+}
+
+func TestKeyBadParams(t *testing.T) {
+	if _, err := Key([]byte("p"), []byte("s"), 15, 8, 1, 32); err == nil {
+		t.Error("N=15 (not a power of 2): expected error, got nil")
+	}
+	if _, err := Key([]byte("p"), []byte("s"), 1, 8, 1, 32); err == nil {
+		t.Error("N=1: expected error, got nil")
+	}
+	if _, err := Key([]byte("p"), []byte("s"), 16, 1<<31, 1<<31, 32); err == nil {
+		t.Error("r*p overflow: expected error, got nil")
+	}
+	if _, err := Key([]byte("p"), []byte("s"), 16, 0, 1, 32); err == nil {
+		t.Error("r=0: expected error, got nil")
+	}
+	if _, err := Key([]byte("p"), []byte("s"), 16, 1, 0, 32); err == nil {
+		t.Error("p=0: expected error, got nil")
+	}
+}
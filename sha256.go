@@ -0,0 +1,162 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastpbkdf2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"hash"
+)
+
+// SHA256 derives a key from the password, salt and iteration count using
+// PBKDF2-HMAC-SHA256, returning a []byte of length keylen that can be used
+// as cryptographic key. See SHA1 for details of the algorithm; this applies
+// the same precomputed-HMAC-state trick to SHA-256.
+func SHA256(password, salt []byte, iter, keyLen int) []byte {
+	return sha2_256family(password, salt, iter, keyLen, sha256.New, sha256_init, sha256.Size, false)
+}
+
+// SHA224 derives a key from the password, salt and iteration count using
+// PBKDF2-HMAC-SHA224. It shares the SHA-256 compression function with SHA256
+// and differs only in its initial state and truncated output size.
+func SHA224(password, salt []byte, iter, keyLen int) []byte {
+	return sha2_256family(password, salt, iter, keyLen, sha256.New224, sha224_init, sha256.Size224, true)
+}
+
+// sha2_256family implements PBKDF2-HMAC for the SHA-256-family hashes
+// (SHA-256 and SHA-224), which share a block size, word size and
+// compression function and differ only in initial state and digest size.
+//
+// The fast U_n = PRF(password, U_(n-1)) path feeds each compression's output
+// state directly back in as the next block's content, relying on the 0x80
+// padding byte and length field living in words the compression doesn't
+// touch. That only holds when the digest fills the whole internal state
+// (SHA-256), so the truncated variant (SHA-224) must repad between and after
+// the inner/outer compressions instead; truncated selects that slower path.
+func sha2_256family(password, salt []byte, iter, keyLen int, newHash func() hash.Hash, initFn func(*block), digestSize int, truncated bool) []byte {
+	prf := hmac.New(newHash, password)
+	numBlocks := (keyLen + digestSize - 1) / digestSize
+
+	var inner, outer block
+	hmac_init256(&inner, &outer, password, newHash, initFn)
+
+	var buf [4]byte
+	var tmp block
+	var U block
+	dk := make([]byte, 0, numBlocks*digestSize)
+	tpad := make([]byte, sha256.BlockSize)
+	for block := 1; block <= numBlocks; block++ {
+		// N.B.: || means concatenation, ^ means XOR
+		// for each block T_i = U_1 ^ U_2 ^ ... ^ U_iter
+		// U_1 = PRF(password, salt || uint(i))
+		prf.Reset()
+		prf.Write(salt)
+		putUint32(buf[:], uint32(block))
+		prf.Write(buf[:4])
+		dk = prf.Sum(dk)
+		T := dk[len(dk)-digestSize:]
+
+		//sha256_input(&tmp, T)
+		for i := range tmp.h[:digestSize/4] {
+			tmp.h[i] = readUint32(T[i*4:])
+		}
+		// U_n = PRF(password, U_(n-1))
+		copy(tpad, T)
+		sha2_pad(tpad, uint(sha256.BlockSize+digestSize), sha256.BlockSize)
+		sha256_input(&U, tpad)
+		if truncated {
+			for n := 2; n <= iter; n++ {
+				sha256_block(&U, &inner, &U)
+				sha256_retrunc(&U, tpad, digestSize)
+				sha256_block(&U, &outer, &U)
+				sha256_retrunc(&U, tpad, digestSize)
+				for i := 0; i*4 < digestSize; i++ {
+					tmp.h[i] ^= U.h[i]
+				}
+			}
+		} else {
+			for n := 2; n <= iter; n++ {
+				sha256_block(&U, &inner, &U)
+				sha256_block(&U, &outer, &U)
+				for i := 0; i*4 < digestSize; i++ {
+					tmp.h[i] ^= U.h[i]
+				}
+			}
+		}
+		sha256_output(T, &tmp)
+	}
+	return dk[:keyLen]
+}
+
+// sha256_retrunc re-truncates and re-pads U's state in place to digestSize
+// bytes using tpad as scratch space, for use between the inner and outer
+// compressions of a truncated SHA-2 variant (SHA-224).
+func sha256_retrunc(U *block, tpad []byte, digestSize int) {
+	sha256_output(tpad[:digestSize], U)
+	sha2_pad(tpad, uint(sha256.BlockSize+digestSize), sha256.BlockSize)
+	sha256_input(U, tpad)
+}
+
+func sha256_init(b *block) {
+	b.h[0] = 0x6a09e667
+	b.h[1] = 0xbb67ae85
+	b.h[2] = 0x3c6ef372
+	b.h[3] = 0xa54ff53a
+	b.h[4] = 0x510e527f
+	b.h[5] = 0x9b05688c
+	b.h[6] = 0x1f83d9ab
+	b.h[7] = 0x5be0cd19
+}
+
+func sha224_init(b *block) {
+	b.h[0] = 0xc1059ed8
+	b.h[1] = 0x367cd507
+	b.h[2] = 0x3070dd17
+	b.h[3] = 0xf70e5939
+	b.h[4] = 0xffc00b31
+	b.h[5] = 0x68581511
+	b.h[6] = 0x64f98fa7
+	b.h[7] = 0xbefa4fa4
+}
+
+func sha256_input(bl *block, b []byte) {
+	for i := range &bl.h {
+		bl.h[i] = readUint32(b[i*4:])
+	}
+}
+
+// sha256_output writes len(b)/4 words of bl's state to b, so callers asking
+// for a truncated digest (SHA-224) simply pass a shorter b.
+func sha256_output(b []byte, bl *block) {
+	for i := 0; i*4 < len(b); i++ {
+		putUint32(b[i*4:], bl.h[i])
+	}
+}
+
+func hmac_init256(inner, outer *block, key []byte, newHash func() hash.Hash, initFn func(*block)) {
+	if len(key) > sha256.BlockSize {
+		h := newHash()
+		h.Write(key)
+		key = h.Sum(nil)
+	}
+
+	ipad := make([]byte, sha256.BlockSize)
+	opad := make([]byte, sha256.BlockSize)
+	copy(ipad, key)
+	copy(opad, key)
+	for i := range ipad {
+		ipad[i] ^= 0x36
+	}
+	for i := range opad {
+		opad[i] ^= 0x5c
+	}
+
+	var init block
+	initFn(&init)
+	sha256_input(inner, ipad)
+	sha256_input(outer, opad)
+	sha256_block(inner, &init, inner)
+	sha256_block(outer, &init, outer)
+}
@@ -0,0 +1,61 @@
+package fastpbkdf2
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func sha256_sum(msg []byte) []byte {
+	if len(msg) > 55 {
+		panic("msg too long")
+	}
+	var ctx block
+	var in block
+	pad := make([]byte, sha256.BlockSize)
+	out := make([]byte, sha256.Size)
+	sha256_init(&ctx)
+	copy(pad, msg)
+	sha2_pad(pad, uint(len(msg)), sha256.BlockSize)
+	sha256_input(&in, pad)
+	sha256_block(&ctx, &ctx, &in)
+	sha256_output(out, &ctx)
+	return out
+}
+
+func Test_sha256(t *testing.T) {
+	msg := []byte("password")
+	got := sha256_sum(msg)
+	want := sha256.Sum256(msg)
+	if !bytes.Equal(got, want[:]) {
+		t.Errorf("got %x, want %x", got, want[:])
+	}
+}
+
+// Test_SHA256_matchesXCrypto and Test_SHA224_matchesXCrypto cross-check
+// against golang.org/x/crypto/pbkdf2 with iter > 1 and a keyLen spanning
+// more than one PRF block, so the U_n = PRF(U_(n-1)) iteration path is
+// exercised for both the full-width SHA-256 output and the truncated
+// SHA-224 retrunc path (sha2_256family's "truncated" branch); Test_sha256
+// above only covers a single compression.
+func Test_SHA256_matchesXCrypto(t *testing.T) {
+	password, salt := []byte("passwordPASSWORDpassword"), []byte("saltSALTsaltSALTsaltSALTsaltSALTsalt")
+	iter, keyLen := 4096, 2*sha256.Size+7 // spans 3 PRF blocks
+	got := SHA256(password, salt, iter, keyLen)
+	want := pbkdf2.Key(password, salt, iter, keyLen, sha256.New)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+func Test_SHA224_matchesXCrypto(t *testing.T) {
+	password, salt := []byte("passwordPASSWORDpassword"), []byte("saltSALTsaltSALTsaltSALTsaltSALTsalt")
+	iter, keyLen := 4096, 2*sha256.Size224+7 // spans 3 PRF blocks
+	got := SHA224(password, salt, iter, keyLen)
+	want := pbkdf2.Key(password, salt, iter, keyLen, sha256.New224)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
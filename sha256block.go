@@ -0,0 +1,84 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastpbkdf2
+
+var _K256 = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5,
+	0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3,
+	0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc,
+	0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7,
+	0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13,
+	0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3,
+	0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5,
+	0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208,
+	0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+// sha256_block computes one SHA-256/SHA-224 compression step: it reads the
+// 256-bit state from init, the 512-bit message block from src (as 16 words
+// held in a circular buffer, the same trick sha1_block_generic uses), and
+// writes the updated 256-bit state to dst.
+func sha256_block(dst, init, src *block) {
+	w := src.h
+	h0, h1, h2, h3, h4, h5, h6, h7 := init.h[0], init.h[1], init.h[2], init.h[3], init.h[4], init.h[5], init.h[6], init.h[7]
+	a, b, c, d, e, f, g, h := h0, h1, h2, h3, h4, h5, h6, h7
+
+	i := 0
+	for ; i < 16; i++ {
+		t1 := h + bigSigma1_256(e) + ch256(e, f, g) + _K256[i] + w[i&0xf]
+		t2 := bigSigma0_256(a) + maj256(a, b, c)
+		h, g, f, e, d, c, b, a = g, f, e, d+t1, c, b, a, t1+t2
+	}
+	for ; i < 64; i++ {
+		w15 := w[(i-15)&0xf]
+		w2 := w[(i-2)&0xf]
+		s0 := smallSigma0_256(w15)
+		s1 := smallSigma1_256(w2)
+		w[i&0xf] = w[(i-16)&0xf] + s0 + w[(i-7)&0xf] + s1
+
+		t1 := h + bigSigma1_256(e) + ch256(e, f, g) + _K256[i] + w[i&0xf]
+		t2 := bigSigma0_256(a) + maj256(a, b, c)
+		h, g, f, e, d, c, b, a = g, f, e, d+t1, c, b, a, t1+t2
+	}
+
+	dst.h[0] = h0 + a
+	dst.h[1] = h1 + b
+	dst.h[2] = h2 + c
+	dst.h[3] = h3 + d
+	dst.h[4] = h4 + e
+	dst.h[5] = h5 + f
+	dst.h[6] = h6 + g
+	dst.h[7] = h7 + h
+}
+
+func ch256(x, y, z uint32) uint32  { return (x & y) ^ (^x & z) }
+func maj256(x, y, z uint32) uint32 { return (x & y) ^ (x & z) ^ (y & z) }
+
+func bigSigma0_256(x uint32) uint32 {
+	return rotr32(x, 2) ^ rotr32(x, 13) ^ rotr32(x, 22)
+}
+
+func bigSigma1_256(x uint32) uint32 {
+	return rotr32(x, 6) ^ rotr32(x, 11) ^ rotr32(x, 25)
+}
+
+func smallSigma0_256(x uint32) uint32 {
+	return rotr32(x, 7) ^ rotr32(x, 18) ^ (x >> 3)
+}
+
+func smallSigma1_256(x uint32) uint32 {
+	return rotr32(x, 17) ^ rotr32(x, 19) ^ (x >> 10)
+}
+
+func rotr32(x uint32, n uint) uint32 {
+	return x<<(32-n) | x>>n
+}
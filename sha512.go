@@ -0,0 +1,158 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastpbkdf2
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"hash"
+)
+
+// SHA512 derives a key from the password, salt and iteration count using
+// PBKDF2-HMAC-SHA512, returning a []byte of length keylen that can be used
+// as cryptographic key. See SHA1 for details of the algorithm; this applies
+// the same precomputed-HMAC-state trick to SHA-512.
+func SHA512(password, salt []byte, iter, keyLen int) []byte {
+	return sha2_512family(password, salt, iter, keyLen, sha512.New, sha512_init, sha512.Size, false)
+}
+
+// SHA384 derives a key from the password, salt and iteration count using
+// PBKDF2-HMAC-SHA384. It shares the SHA-512 compression function with SHA512
+// and differs only in its initial state and truncated output size.
+func SHA384(password, salt []byte, iter, keyLen int) []byte {
+	return sha2_512family(password, salt, iter, keyLen, sha512.New384, sha384_init, sha512.Size384, true)
+}
+
+// sha2_512family implements PBKDF2-HMAC for the SHA-512-family hashes
+// (SHA-512 and SHA-384), which share a block size, word size and
+// compression function and differ only in initial state and digest size.
+//
+// See sha2_256family for why the truncated variant (SHA-384) needs the
+// slower repad-between-compressions path instead of the in-place trick.
+func sha2_512family(password, salt []byte, iter, keyLen int, newHash func() hash.Hash, initFn func(*block64), digestSize int, truncated bool) []byte {
+	prf := hmac.New(newHash, password)
+	numBlocks := (keyLen + digestSize - 1) / digestSize
+
+	var inner, outer block64
+	hmac_init512(&inner, &outer, password, newHash, initFn)
+
+	var buf [4]byte
+	var tmp block64
+	var U block64
+	dk := make([]byte, 0, numBlocks*digestSize)
+	tpad := make([]byte, sha512.BlockSize)
+	for block := 1; block <= numBlocks; block++ {
+		// N.B.: || means concatenation, ^ means XOR
+		// for each block T_i = U_1 ^ U_2 ^ ... ^ U_iter
+		// U_1 = PRF(password, salt || uint(i))
+		prf.Reset()
+		prf.Write(salt)
+		putUint32(buf[:], uint32(block))
+		prf.Write(buf[:4])
+		dk = prf.Sum(dk)
+		T := dk[len(dk)-digestSize:]
+
+		//sha512_input(&tmp, T)
+		for i := range tmp.h[:digestSize/8] {
+			tmp.h[i] = readUint64(T[i*8:])
+		}
+		// U_n = PRF(password, U_(n-1))
+		copy(tpad, T)
+		sha2_pad(tpad, uint(sha512.BlockSize+digestSize), sha512.BlockSize)
+		sha512_input(&U, tpad)
+		if truncated {
+			for n := 2; n <= iter; n++ {
+				sha512_block(&U, &inner, &U)
+				sha512_retrunc(&U, tpad, digestSize)
+				sha512_block(&U, &outer, &U)
+				sha512_retrunc(&U, tpad, digestSize)
+				for i := 0; i*8 < digestSize; i++ {
+					tmp.h[i] ^= U.h[i]
+				}
+			}
+		} else {
+			for n := 2; n <= iter; n++ {
+				sha512_block(&U, &inner, &U)
+				sha512_block(&U, &outer, &U)
+				for i := 0; i*8 < digestSize; i++ {
+					tmp.h[i] ^= U.h[i]
+				}
+			}
+		}
+		sha512_output(T, &tmp)
+	}
+	return dk[:keyLen]
+}
+
+// sha512_retrunc re-truncates and re-pads U's state in place to digestSize
+// bytes using tpad as scratch space, for use between the inner and outer
+// compressions of a truncated SHA-2 variant (SHA-384).
+func sha512_retrunc(U *block64, tpad []byte, digestSize int) {
+	sha512_output(tpad[:digestSize], U)
+	sha2_pad(tpad, uint(sha512.BlockSize+digestSize), sha512.BlockSize)
+	sha512_input(U, tpad)
+}
+
+func sha512_init(b *block64) {
+	b.h[0] = 0x6a09e667f3bcc908
+	b.h[1] = 0xbb67ae8584caa73b
+	b.h[2] = 0x3c6ef372fe94f82b
+	b.h[3] = 0xa54ff53a5f1d36f1
+	b.h[4] = 0x510e527fade682d1
+	b.h[5] = 0x9b05688c2b3e6c1f
+	b.h[6] = 0x1f83d9abfb41bd6b
+	b.h[7] = 0x5be0cd19137e2179
+}
+
+func sha384_init(b *block64) {
+	b.h[0] = 0xcbbb9d5dc1059ed8
+	b.h[1] = 0x629a292a367cd507
+	b.h[2] = 0x9159015a3070dd17
+	b.h[3] = 0x152fecd8f70e5939
+	b.h[4] = 0x67332667ffc00b31
+	b.h[5] = 0x8eb44a8768581511
+	b.h[6] = 0xdb0c2e0d64f98fa7
+	b.h[7] = 0x47b5481dbefa4fa4
+}
+
+func sha512_input(bl *block64, b []byte) {
+	for i := range &bl.h {
+		bl.h[i] = readUint64(b[i*8:])
+	}
+}
+
+// sha512_output writes len(b)/8 words of bl's state to b, so callers asking
+// for a truncated digest (SHA-384) simply pass a shorter b.
+func sha512_output(b []byte, bl *block64) {
+	for i := 0; i*8 < len(b); i++ {
+		putUint64(b[i*8:], bl.h[i])
+	}
+}
+
+func hmac_init512(inner, outer *block64, key []byte, newHash func() hash.Hash, initFn func(*block64)) {
+	if len(key) > sha512.BlockSize {
+		h := newHash()
+		h.Write(key)
+		key = h.Sum(nil)
+	}
+
+	ipad := make([]byte, sha512.BlockSize)
+	opad := make([]byte, sha512.BlockSize)
+	copy(ipad, key)
+	copy(opad, key)
+	for i := range ipad {
+		ipad[i] ^= 0x36
+	}
+	for i := range opad {
+		opad[i] ^= 0x5c
+	}
+
+	var init block64
+	initFn(&init)
+	sha512_input(inner, ipad)
+	sha512_input(outer, opad)
+	sha512_block(inner, &init, inner)
+	sha512_block(outer, &init, outer)
+}
@@ -0,0 +1,59 @@
+package fastpbkdf2
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func sha512_sum(msg []byte) []byte {
+	if len(msg) > 111 {
+		panic("msg too long")
+	}
+	var ctx block64
+	var in block64
+	pad := make([]byte, sha512.BlockSize)
+	out := make([]byte, sha512.Size)
+	sha512_init(&ctx)
+	copy(pad, msg)
+	sha2_pad(pad, uint(len(msg)), sha512.BlockSize)
+	sha512_input(&in, pad)
+	sha512_block(&ctx, &ctx, &in)
+	sha512_output(out, &ctx)
+	return out
+}
+
+func Test_sha512(t *testing.T) {
+	msg := []byte("password")
+	got := sha512_sum(msg)
+	want := sha512.Sum512(msg)
+	if !bytes.Equal(got, want[:]) {
+		t.Errorf("got %x, want %x", got, want[:])
+	}
+}
+
+// Test_SHA512_matchesXCrypto and Test_SHA384_matchesXCrypto cross-check
+// against golang.org/x/crypto/pbkdf2 with iter > 1 and a keyLen spanning
+// more than one PRF block; see the equivalent SHA-256/SHA-224 tests for why
+// this matters for the U_n = PRF(U_(n-1)) iteration and retrunc paths.
+func Test_SHA512_matchesXCrypto(t *testing.T) {
+	password, salt := []byte("passwordPASSWORDpassword"), []byte("saltSALTsaltSALTsaltSALTsaltSALTsalt")
+	iter, keyLen := 4096, 2*sha512.Size+7 // spans 3 PRF blocks
+	got := SHA512(password, salt, iter, keyLen)
+	want := pbkdf2.Key(password, salt, iter, keyLen, sha512.New)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
+
+func Test_SHA384_matchesXCrypto(t *testing.T) {
+	password, salt := []byte("passwordPASSWORDpassword"), []byte("saltSALTsaltSALTsaltSALTsaltSALTsalt")
+	iter, keyLen := 4096, 2*sha512.Size384+7 // spans 3 PRF blocks
+	got := SHA384(password, salt, iter, keyLen)
+	want := pbkdf2.Key(password, salt, iter, keyLen, sha512.New384)
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %x, want %x", got, want)
+	}
+}
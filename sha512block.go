@@ -0,0 +1,94 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastpbkdf2
+
+var _K512 = [80]uint64{
+	0x428a2f98d728ae22, 0x7137449123ef65cd, 0xb5c0fbcfec4d3b2f, 0xe9b5dba58189dbbc,
+	0x3956c25bf348b538, 0x59f111f1b605d019, 0x923f82a4af194f9b, 0xab1c5ed5da6d8118,
+	0xd807aa98a3030242, 0x12835b0145706fbe, 0x243185be4ee4b28c, 0x550c7dc3d5ffb4e2,
+	0x72be5d74f27b896f, 0x80deb1fe3b1696b1, 0x9bdc06a725c71235, 0xc19bf174cf692694,
+	0xe49b69c19ef14ad2, 0xefbe4786384f25e3, 0x0fc19dc68b8cd5b5, 0x240ca1cc77ac9c65,
+	0x2de92c6f592b0275, 0x4a7484aa6ea6e483, 0x5cb0a9dcbd41fbd4, 0x76f988da831153b5,
+	0x983e5152ee66dfab, 0xa831c66d2db43210, 0xb00327c898fb213f, 0xbf597fc7beef0ee4,
+	0xc6e00bf33da88fc2, 0xd5a79147930aa725, 0x06ca6351e003826f, 0x142929670a0e6e70,
+	0x27b70a8546d22ffc, 0x2e1b21385c26c926, 0x4d2c6dfc5ac42aed, 0x53380d139d95b3df,
+	0x650a73548baf63de, 0x766a0abb3c77b2a8, 0x81c2c92e47edaee6, 0x92722c851482353b,
+	0xa2bfe8a14cf10364, 0xa81a664bbc423001, 0xc24b8b70d0f89791, 0xc76c51a30654be30,
+	0xd192e819d6ef5218, 0xd69906245565a910, 0xf40e35855771202a, 0x106aa07032bbd1b8,
+	0x19a4c116b8d2d0c8, 0x1e376c085141ab53, 0x2748774cdf8eeb99, 0x34b0bcb5e19b48a8,
+	0x391c0cb3c5c95a63, 0x4ed8aa4ae3418acb, 0x5b9cca4f7763e373, 0x682e6ff3d6b2b8a3,
+	0x748f82ee5defb2fc, 0x78a5636f43172f60, 0x84c87814a1f0ab72, 0x8cc702081a6439ec,
+	0x90befffa23631e28, 0xa4506cebde82bde9, 0xbef9a3f7b2c67915, 0xc67178f2e372532b,
+	0xca273eceea26619c, 0xd186b8c721c0c207, 0xeada7dd6cde0eb1e, 0xf57d4f7fee6ed178,
+	0x06f067aa72176fba, 0x0a637dc5a2c898a6, 0x113f9804bef90dae, 0x1b710b35131c471b,
+	0x28db77f523047d84, 0x32caab7b40c72493, 0x3c9ebe0a15c9bebc, 0x431d67c49c100d4c,
+	0x4cc5d4becb3e42b6, 0x597f299cfc657e2a, 0x5fcb6fab3ad6faec, 0x6c44198c4a475817,
+}
+
+// block64 holds the 128-byte block or 512-bit state used by the SHA-512
+// family (SHA-512/SHA-384), the 64-bit-word counterpart to block.
+type block64 struct {
+	h [16]uint64
+}
+
+// sha512_block computes one SHA-512/SHA-384 compression step: it reads the
+// 512-bit state from init, the 1024-bit message block from src (as 16 words
+// held in a circular buffer, the same trick sha1_block_generic uses), and
+// writes the updated 512-bit state to dst.
+func sha512_block(dst, init, src *block64) {
+	w := src.h
+	h0, h1, h2, h3, h4, h5, h6, h7 := init.h[0], init.h[1], init.h[2], init.h[3], init.h[4], init.h[5], init.h[6], init.h[7]
+	a, b, c, d, e, f, g, h := h0, h1, h2, h3, h4, h5, h6, h7
+
+	i := 0
+	for ; i < 16; i++ {
+		t1 := h + bigSigma1_512(e) + ch512(e, f, g) + _K512[i] + w[i&0xf]
+		t2 := bigSigma0_512(a) + maj512(a, b, c)
+		h, g, f, e, d, c, b, a = g, f, e, d+t1, c, b, a, t1+t2
+	}
+	for ; i < 80; i++ {
+		w15 := w[(i-15)&0xf]
+		w2 := w[(i-2)&0xf]
+		s0 := smallSigma0_512(w15)
+		s1 := smallSigma1_512(w2)
+		w[i&0xf] = w[(i-16)&0xf] + s0 + w[(i-7)&0xf] + s1
+
+		t1 := h + bigSigma1_512(e) + ch512(e, f, g) + _K512[i] + w[i&0xf]
+		t2 := bigSigma0_512(a) + maj512(a, b, c)
+		h, g, f, e, d, c, b, a = g, f, e, d+t1, c, b, a, t1+t2
+	}
+
+	dst.h[0] = h0 + a
+	dst.h[1] = h1 + b
+	dst.h[2] = h2 + c
+	dst.h[3] = h3 + d
+	dst.h[4] = h4 + e
+	dst.h[5] = h5 + f
+	dst.h[6] = h6 + g
+	dst.h[7] = h7 + h
+}
+
+func ch512(x, y, z uint64) uint64  { return (x & y) ^ (^x & z) }
+func maj512(x, y, z uint64) uint64 { return (x & y) ^ (x & z) ^ (y & z) }
+
+func bigSigma0_512(x uint64) uint64 {
+	return rotr64(x, 28) ^ rotr64(x, 34) ^ rotr64(x, 39)
+}
+
+func bigSigma1_512(x uint64) uint64 {
+	return rotr64(x, 14) ^ rotr64(x, 18) ^ rotr64(x, 41)
+}
+
+func smallSigma0_512(x uint64) uint64 {
+	return rotr64(x, 1) ^ rotr64(x, 8) ^ (x >> 7)
+}
+
+func smallSigma1_512(x uint64) uint64 {
+	return rotr64(x, 19) ^ rotr64(x, 61) ^ (x >> 6)
+}
+
+func rotr64(x uint64, n uint) uint64 {
+	return x<<(64-n) | x>>n
+}
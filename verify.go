@@ -0,0 +1,22 @@
+// Copyright 2012 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fastpbkdf2
+
+import (
+	"crypto/subtle"
+	"hash"
+)
+
+// Verify reports whether deriving len(expected) bytes from password, salt
+// and iter using the HMAC variant of h produces expected, comparing in
+// constant time via crypto/subtle.ConstantTimeCompare.
+//
+// Verify exists because the natural way to check a stored PBKDF2 hash —
+// decode it, call Key, and compare with == or bytes.Equal — compares in
+// variable time, leaking information about how many leading bytes matched.
+func Verify(password, salt []byte, iter int, expected []byte, h func() hash.Hash) bool {
+	got := Key(password, salt, iter, len(expected), h)
+	return subtle.ConstantTimeCompare(got, expected) == 1
+}
@@ -0,0 +1,20 @@
+package fastpbkdf2
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	password, salt := []byte("password"), []byte("salt")
+	expected := SHA256(password, salt, 100, 32)
+	if !Verify(password, salt, 100, expected, sha256.New) {
+		t.Error("Verify: want true for matching password")
+	}
+
+	bad := append([]byte(nil), expected...)
+	bad[0] ^= 0xff
+	if Verify(password, salt, 100, bad, sha256.New) {
+		t.Error("Verify: want false for mismatching hash")
+	}
+}